@@ -0,0 +1,237 @@
+package pcf
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DecodeBDF reads a BDF (Glyph Bitmap Distribution Format) font into the
+// neutral Font model.
+func DecodeBDF(r io.Reader) (*Font, error) {
+	font := newFont()
+
+	var (
+		cur                    *Glyph
+		curRune                rune
+		curWidth, curHeight    int
+		curXOff, curYOff       int
+		bitmapRows             []string
+		inBitmap, inProperties bool
+	)
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		kw := fields[0]
+
+		if inBitmap {
+			if kw == "ENDCHAR" {
+				raw := decodeBDFRows(bitmapRows, curWidth, curHeight)
+				cur.Bitmap = unpackBitmap(raw, PCF_BIT_MASK, curWidth, curHeight)
+				cur.Metric.LeftSideBearing = curXOff
+				cur.Metric.RightSideBearing = curXOff + curWidth
+				cur.Metric.CharAscent = curHeight + curYOff
+				cur.Metric.CharDescent = -curYOff
+				font.Glyphs[curRune] = cur
+				inBitmap = false
+				cur = nil
+				bitmapRows = nil
+			} else {
+				bitmapRows = append(bitmapRows, kw)
+			}
+			continue
+		}
+
+		if inProperties {
+			if kw == "ENDPROPERTIES" {
+				inProperties = false
+				continue
+			}
+			if len(fields) >= 2 {
+				font.Properties[kw] = parseBDFPropertyValue(strings.Join(fields[1:], " "))
+			}
+			continue
+		}
+
+		switch kw {
+		case "FONT":
+			font.Properties["FONT"] = strings.Join(fields[1:], " ")
+		case "STARTPROPERTIES":
+			inProperties = true
+		case "STARTCHAR":
+			cur = &Glyph{Name: strings.Join(fields[1:], " ")}
+		case "ENCODING":
+			if len(fields) >= 2 {
+				code, _ := strconv.Atoi(fields[1])
+				curRune = rune(code)
+			}
+		case "SWIDTH":
+			if cur != nil && len(fields) >= 2 {
+				w, _ := strconv.Atoi(fields[1])
+				cur.ScalableWidth = w
+			}
+		case "DWIDTH":
+			if cur != nil && len(fields) >= 2 {
+				w, _ := strconv.Atoi(fields[1])
+				cur.Metric.CharWidth = w
+			}
+		case "BBX":
+			if len(fields) >= 5 {
+				curWidth, _ = strconv.Atoi(fields[1])
+				curHeight, _ = strconv.Atoi(fields[2])
+				curXOff, _ = strconv.Atoi(fields[3])
+				curYOff, _ = strconv.Atoi(fields[4])
+			}
+		case "BITMAP":
+			inBitmap = true
+			bitmapRows = nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	if dc, ok := font.Properties["DEFAULT_CHAR"].(int); ok {
+		font.DefaultChar = rune(dc)
+	}
+	if a, ok := font.Properties["FONT_ASCENT"].(int); ok {
+		font.Ascent = a
+	}
+	if d, ok := font.Properties["FONT_DESCENT"].(int); ok {
+		font.Descent = d
+	}
+
+	return font, nil
+}
+
+func parseBDFPropertyValue(v string) interface{} {
+	if strings.HasPrefix(v, "\"") && strings.HasSuffix(v, "\"") && len(v) >= 2 {
+		return v[1 : len(v)-1]
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		return n
+	}
+	return v
+}
+
+func decodeBDFRows(rows []string, width, height int) []byte {
+	rowBytes := (width + 7) / 8
+	out := make([]byte, rowBytes*height)
+	for y, hexRow := range rows {
+		if y >= height {
+			break
+		}
+		b, err := hex.DecodeString(strings.TrimSpace(hexRow))
+		if err != nil {
+			continue
+		}
+		n := rowBytes
+		if len(b) < n {
+			n = len(b)
+		}
+		copy(out[y*rowBytes:y*rowBytes+n], b[:n])
+	}
+	return out
+}
+
+// EncodeBDF writes a Font out as a BDF file.
+func EncodeBDF(w io.Writer, f *Font) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "STARTFONT 2.1")
+	name, _ := f.Properties["FONT"].(string)
+	if name == "" {
+		name = "untitled"
+	}
+	fmt.Fprintf(bw, "FONT %s\n", name)
+	fmt.Fprintln(bw, "SIZE 16 75 75")
+
+	minX, minY, maxX, maxY := 0, 0, 0, 0
+	for i, r := range f.sortedRunes() {
+		g := f.Glyphs[r]
+		w := g.Metric.RightSideBearing - g.Metric.LeftSideBearing
+		h := g.Metric.CharAscent + g.Metric.CharDescent
+		x0, y0 := g.Metric.LeftSideBearing, -g.Metric.CharDescent
+		x1, y1 := x0+w, y0+h
+		if i == 0 || x0 < minX {
+			minX = x0
+		}
+		if i == 0 || y0 < minY {
+			minY = y0
+		}
+		if i == 0 || x1 > maxX {
+			maxX = x1
+		}
+		if i == 0 || y1 > maxY {
+			maxY = y1
+		}
+	}
+	fmt.Fprintf(bw, "FONTBOUNDINGBOX %d %d %d %d\n", maxX-minX, maxY-minY, minX, minY)
+
+	propKeys := make([]string, 0, len(f.Properties))
+	for k := range f.Properties {
+		if k == "FONT" {
+			continue
+		}
+		propKeys = append(propKeys, k)
+	}
+	sort.Strings(propKeys)
+	if len(propKeys) > 0 {
+		fmt.Fprintf(bw, "STARTPROPERTIES %d\n", len(propKeys))
+		for _, k := range propKeys {
+			switch v := f.Properties[k].(type) {
+			case string:
+				fmt.Fprintf(bw, "%s \"%s\"\n", k, v)
+			case int:
+				fmt.Fprintf(bw, "%s %d\n", k, v)
+			}
+		}
+		fmt.Fprintln(bw, "ENDPROPERTIES")
+	}
+
+	runes := f.sortedRunes()
+	fmt.Fprintf(bw, "CHARS %d\n", len(runes))
+	for _, r := range runes {
+		g := f.Glyphs[r]
+		w := g.Metric.RightSideBearing - g.Metric.LeftSideBearing
+		h := g.Metric.CharAscent + g.Metric.CharDescent
+
+		name := g.Name
+		if name == "" {
+			name = fmt.Sprintf("char%d", r)
+		}
+		swidth := g.ScalableWidth
+		if swidth == 0 {
+			// No scalable width carried over (e.g. a Font built by hand
+			// rather than decoded from BDF/PCF): approximate it from the
+			// device width, same as bdftopcf does in reverse.
+			swidth = g.Metric.CharWidth * 1000
+		}
+
+		fmt.Fprintf(bw, "STARTCHAR %s\n", name)
+		fmt.Fprintf(bw, "ENCODING %d\n", int(r))
+		fmt.Fprintf(bw, "SWIDTH %d 0\n", swidth)
+		fmt.Fprintf(bw, "DWIDTH %d 0\n", g.Metric.CharWidth)
+		fmt.Fprintf(bw, "BBX %d %d %d %d\n", w, h, g.Metric.LeftSideBearing, -g.Metric.CharDescent)
+		fmt.Fprintln(bw, "BITMAP")
+
+		raw := packBitmap(g.Bitmap, PCF_BIT_MASK)
+		rowBytes := (w + 7) / 8
+		for y := 0; y < h; y++ {
+			row := raw[y*rowBytes : (y+1)*rowBytes]
+			fmt.Fprintln(bw, strings.ToUpper(hex.EncodeToString(row)))
+		}
+		fmt.Fprintln(bw, "ENDCHAR")
+	}
+
+	fmt.Fprintln(bw, "ENDFONT")
+	return bw.Flush()
+}
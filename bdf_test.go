@@ -0,0 +1,61 @@
+package pcf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestBDFRoundTrip(t *testing.T) {
+	bitmap := image.NewAlpha(image.Rect(0, 0, 4, 6))
+	bitmap.SetAlpha(0, 0, color.Alpha{A: 0xff})
+	bitmap.SetAlpha(3, 5, color.Alpha{A: 0xff})
+
+	font := newFont()
+	font.Properties["FONT"] = "Test"
+	font.Glyphs['A'] = &Glyph{
+		Name: "A",
+		Metric: MetricEntry{
+			RightSideBearing: 4,
+			CharWidth:        5,
+			CharAscent:       5,
+			CharDescent:      1,
+		},
+		ScalableWidth: 312,
+		Bitmap:        bitmap,
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeBDF(&buf, font); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := DecodeBDF(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, ok := got.Glyphs['A']
+	if !ok {
+		t.Fatal("glyph 'A' missing after round trip")
+	}
+	if g.Metric.CharWidth != 5 {
+		t.Errorf("CharWidth = %d, want 5", g.Metric.CharWidth)
+	}
+	if g.ScalableWidth != 312 {
+		t.Errorf("ScalableWidth = %d, want 312", g.ScalableWidth)
+	}
+	if got.Properties["FONT"] != "Test" {
+		t.Errorf("FONT = %v, want Test", got.Properties["FONT"])
+	}
+
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 4; x++ {
+			want := (x == 0 && y == 0) || (x == 3 && y == 5)
+			got := g.Bitmap.AlphaAt(x, y).A != 0
+			if got != want {
+				t.Errorf("Bitmap.AlphaAt(%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package pcf
+
+import (
+	"image"
+	"image/color"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// FaceOptions controls how a Face renders glyphs.
+type FaceOptions struct {
+	// Foreground is the color glyphs are drawn in. A *image.Uniform of
+	// this color is returned by Src, for use as the Src of a font.Drawer.
+	// Defaults to black.
+	Foreground color.Color
+}
+
+// Face adapts a PCFFile to the golang.org/x/image/font.Face interface so
+// PCF fonts can be used with font.Drawer and image/draw.
+type Face struct {
+	pf   *PCFFile
+	opts FaceOptions
+}
+
+// NewFace returns a font.Face backed by pf. If opts is nil, default
+// options (black foreground) are used.
+func (pf *PCFFile) NewFace(opts *FaceOptions) *Face {
+	f := &Face{pf: pf}
+	if opts != nil {
+		f.opts = *opts
+	}
+	if f.opts.Foreground == nil {
+		f.opts.Foreground = color.Black
+	}
+	return f
+}
+
+// Src returns a uniform image of the face's foreground color, suitable
+// for use as the Src field of a font.Drawer.
+func (f *Face) Src() image.Image {
+	return image.NewUniform(f.opts.Foreground)
+}
+
+func (f *Face) glyphIndexAndMetric(r rune) (i int, m metricEntry, ok bool) {
+	idx, err := f.pf.encoding.lookup(int(r))
+	if err != nil {
+		return
+	}
+	if err = f.pf.metric.readMeticEntry(f.pf.f, idx, &m); err != nil {
+		return
+	}
+	i, ok = idx, true
+	return
+}
+
+// Glyph implements font.Face.
+func (f *Face) Glyph(dot fixed.Point26_6, r rune) (dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+	i, m, ok := f.glyphIndexAndMetric(r)
+	if !ok {
+		return
+	}
+
+	raw, err := f.pf.bitmap.readData(f.pf.f, i)
+	if err != nil {
+		ok = false
+		return
+	}
+
+	width := m.rightSidedBearing - m.leftSidedBearing
+	height := m.charAscent + m.charDescent
+	alpha := unpackBitmap(raw, f.pf.bitmap.format, width, height)
+
+	x0 := dot.X.Floor() + m.leftSidedBearing
+	y0 := dot.Y.Floor() - m.charAscent
+	dr = image.Rect(x0, y0, x0+width, y0+height)
+	mask = alpha
+	maskp = image.Point{}
+	advance = fixed.I(m.charWidth)
+	ok = true
+	return
+}
+
+// GlyphBounds implements font.Face.
+func (f *Face) GlyphBounds(r rune) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	_, m, ok := f.glyphIndexAndMetric(r)
+	if !ok {
+		return
+	}
+	bounds = fixed.Rectangle26_6{
+		Min: fixed.Point26_6{X: fixed.I(m.leftSidedBearing), Y: fixed.I(-m.charAscent)},
+		Max: fixed.Point26_6{X: fixed.I(m.rightSidedBearing), Y: fixed.I(m.charDescent)},
+	}
+	advance = fixed.I(m.charWidth)
+	return
+}
+
+// GlyphAdvance implements font.Face.
+func (f *Face) GlyphAdvance(r rune) (advance fixed.Int26_6, ok bool) {
+	_, m, ok := f.glyphIndexAndMetric(r)
+	if !ok {
+		return
+	}
+	advance = fixed.I(m.charWidth)
+	return
+}
+
+// Kern implements font.Face. PCF carries no kerning table, so it always
+// returns 0.
+func (f *Face) Kern(r0, r1 rune) fixed.Int26_6 {
+	return 0
+}
+
+// Metrics implements font.Face. It uses the font's accelerator table
+// when present, falling back to scanning every glyph's metric entry for
+// the widest ascent and descent otherwise.
+func (f *Face) Metrics() font.Metrics {
+	if f.pf.accelerators != nil {
+		accel := f.pf.Accelerators()
+		return font.Metrics{
+			Height:  fixed.I(accel.FontAscent + accel.FontDescent),
+			Ascent:  fixed.I(accel.FontAscent),
+			Descent: fixed.I(accel.FontDescent),
+		}
+	}
+
+	var ascent, descent int
+	for i := 0; i < f.pf.metric.count; i++ {
+		var m metricEntry
+		if err := f.pf.metric.readMeticEntry(f.pf.f, i, &m); err != nil {
+			continue
+		}
+		if m.charAscent > ascent {
+			ascent = m.charAscent
+		}
+		if m.charDescent > descent {
+			descent = m.charDescent
+		}
+	}
+	return font.Metrics{
+		Height:  fixed.I(ascent + descent),
+		Ascent:  fixed.I(ascent),
+		Descent: fixed.I(descent),
+	}
+}
+
+// Close implements font.Face. It closes the underlying PCF file.
+func (f *Face) Close() error {
+	return f.pf.f.Close()
+}
+
+// unpackBitmap normalizes a glyph's raw bitmap bytes, laid out per
+// format's padding/byte-order/bit-order/scan-unit bits, into an
+// image.Alpha of the given pixel width and height.
+func unpackBitmap(raw []byte, format int32, width, height int) *image.Alpha {
+	alpha := image.NewAlpha(image.Rect(0, 0, width, height))
+
+	pad := 1 << uint(format&PCF_GLYPH_PAD_MASK)
+	scanUnit := 1 << uint((format&PCF_SCAN_UNIT_MASK)>>4)
+	msbByteOrder := format&PCF_BYTE_MASK != 0
+	msbBitOrder := format&PCF_BIT_MASK != 0
+
+	rowBytes := ((width + pad*8 - 1) / (pad * 8)) * pad
+	if rowBytes == 0 || scanUnit == 0 {
+		return alpha
+	}
+
+	for y := 0; y < height; y++ {
+		rowStart := y * rowBytes
+		if rowStart+rowBytes > len(raw) {
+			break
+		}
+		row := raw[rowStart : rowStart+rowBytes]
+		for x := 0; x < width; x++ {
+			byteIndex := x / 8
+			bitInByte := x % 8
+
+			unit := byteIndex / scanUnit
+			byteInUnit := byteIndex % scanUnit
+			if !msbByteOrder {
+				byteInUnit = scanUnit - 1 - byteInUnit
+			}
+			actualByteIndex := unit*scanUnit + byteInUnit
+			if actualByteIndex >= len(row) {
+				continue
+			}
+			b := row[actualByteIndex]
+
+			var bit byte
+			if msbBitOrder {
+				bit = (b >> uint(7-bitInByte)) & 1
+			} else {
+				bit = (b >> uint(bitInByte)) & 1
+			}
+			if bit != 0 {
+				alpha.SetAlpha(x, y, color.Alpha{A: 0xff})
+			}
+		}
+	}
+
+	return alpha
+}
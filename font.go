@@ -0,0 +1,129 @@
+package pcf
+
+import (
+	"image"
+	"io"
+	"sort"
+)
+
+// Glyph is a single character in a Font: its device metrics, its bitmap,
+// and (for PCF/BDF interop) its textual glyph name and scalable width.
+type Glyph struct {
+	Name   string
+	Metric MetricEntry
+	// ScalableWidth is the glyph's BDF SWIDTH / PCF_SWIDTHS value: the
+	// advance width in 1000ths of the font's point size, as opposed to
+	// Metric.CharWidth's device (pixel) advance. Zero means the font
+	// carried no scalable width.
+	ScalableWidth int
+	Bitmap        *image.Alpha
+}
+
+// Font is a neutral, in-memory representation of a bitmap font, shared
+// by the PCF and BDF codecs so a font can be decoded from one format and
+// encoded to the other.
+type Font struct {
+	Glyphs      map[rune]*Glyph
+	Properties  map[string]interface{}
+	DefaultChar rune
+	Ascent      int
+	Descent     int
+}
+
+func newFont() *Font {
+	return &Font{
+		Glyphs:     map[rune]*Glyph{},
+		Properties: map[string]interface{}{},
+	}
+}
+
+func (f *Font) sortedRunes() []rune {
+	runes := make([]rune, 0, len(f.Glyphs))
+	for r := range f.Glyphs {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return runes
+}
+
+// DecodePCF reads a PCF font into the neutral Font model.
+func DecodePCF(r io.ReadSeeker) (*Font, error) {
+	pf, err := decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	font := newFont()
+	for k, v := range pf.Properties() {
+		font.Properties[k] = v
+	}
+	accel := pf.Accelerators()
+	font.Ascent = accel.FontAscent
+	font.Descent = accel.FontDescent
+
+	// BDF_ENCODINGS' defChar is the authoritative default-glyph fallback
+	// (the same field PCFFile.Lookup falls back to); PCF_PROPERTIES'
+	// DEFAULT_CHAR is an optional, free-form override on top of it. A
+	// defChar of -1 means the font declares no default glyph.
+	if pf.encoding.defChar >= 0 {
+		font.DefaultChar = rune(pf.encoding.defChar)
+	}
+	if dc, ok := font.Properties["DEFAULT_CHAR"].(int); ok {
+		font.DefaultChar = rune(dc)
+	}
+
+	names := pf.GlyphNames()
+	swidths := pf.ScalableWidths()
+	for _, r := range pf.encoding.runes() {
+		idx, err := pf.encoding.lookup(int(r))
+		if err != nil {
+			continue
+		}
+
+		var m metricEntry
+		if err := pf.metric.readMeticEntry(pf.f, idx, &m); err != nil {
+			return nil, err
+		}
+		raw, err := pf.bitmap.readData(pf.f, idx)
+		if err != nil {
+			return nil, err
+		}
+		w := m.rightSidedBearing - m.leftSidedBearing
+		h := m.charAscent + m.charDescent
+		bitmap := unpackBitmap(raw, pf.bitmap.format, w, h)
+
+		var name string
+		if idx < len(names) {
+			name = names[idx]
+		}
+		var swidth int
+		if idx < len(swidths) {
+			swidth = int(swidths[idx])
+		}
+
+		font.Glyphs[r] = &Glyph{Name: name, Metric: newMetricEntry(m), ScalableWidth: swidth, Bitmap: bitmap}
+	}
+
+	return font, nil
+}
+
+// EncodePCF writes a Font out as a PCF file.
+func EncodePCF(w io.WriteSeeker, f *Font) error {
+	pw := NewWriter(w)
+	for k, v := range f.Properties {
+		pw.SetProperty(k, v)
+	}
+	// f.DefaultChar of 0 means the font declared no default glyph (see
+	// DecodePCF); Writer resolves PCF_PROPERTIES' DEFAULT_CHAR to the
+	// matching glyph's encoding-table defChar in Close.
+	if f.DefaultChar != 0 {
+		pw.SetProperty("DEFAULT_CHAR", int(f.DefaultChar))
+	}
+	for _, r := range f.sortedRunes() {
+		g := f.Glyphs[r]
+		if err := pw.AddGlyph(r, g.Name, g.Metric, g.ScalableWidth, g.Bitmap); err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
@@ -0,0 +1,59 @@
+package pcf
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"testing"
+)
+
+func TestPCFFontRoundTrip(t *testing.T) {
+	bitmapA := image.NewAlpha(image.Rect(0, 0, 4, 6))
+	bitmapA.SetAlpha(0, 0, color.Alpha{A: 0xff})
+	bitmapB := image.NewAlpha(image.Rect(0, 0, 4, 6))
+	bitmapB.SetAlpha(3, 5, color.Alpha{A: 0xff})
+
+	font := newFont()
+	font.DefaultChar = 'B'
+	font.Glyphs['A'] = &Glyph{
+		Name:   "A",
+		Metric: MetricEntry{RightSideBearing: 4, CharWidth: 5, CharAscent: 5, CharDescent: 1},
+		Bitmap: bitmapA,
+	}
+	font.Glyphs['B'] = &Glyph{
+		Name:          "B",
+		Metric:        MetricEntry{RightSideBearing: 4, CharWidth: 5, CharAscent: 5, CharDescent: 1},
+		ScalableWidth: 999,
+		Bitmap:        bitmapB,
+	}
+
+	f, err := os.CreateTemp("", "pcf-font-*.pcf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := EncodePCF(f, font); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodePCF(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.DefaultChar != 'B' {
+		t.Errorf("DefaultChar = %q, want 'B'", got.DefaultChar)
+	}
+	g, ok := got.Glyphs['B']
+	if !ok {
+		t.Fatal("glyph 'B' missing after round trip")
+	}
+	if g.ScalableWidth != 999 {
+		t.Errorf("ScalableWidth = %d, want 999", g.ScalableWidth)
+	}
+}
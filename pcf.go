@@ -1,14 +1,12 @@
 package pcf
 
 import (
-	"io"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
-	"reflect"
-	"unsafe"
-
-	_ "image"
 )
 
 const (
@@ -26,8 +24,28 @@ const (
 	PCF_INKBOUNDS		= 0x00000200
 	PCF_ACCEL_W_INKBOUNDS =	0x00000100
 	PCF_COMPRESSED_METRICS	= 0x00000100
+
+	// Bits within a table's format word, per the PCF glyph bitmap format
+	// (see pcf-format.txt): the low two bits select the glyph row
+	// padding, bit 2 selects the byte order and bit 3 the bit order used
+	// for every multi-byte value in the table (including the bitmap
+	// scanline units), and bits 4-5 select the scan unit size.
+	PCF_GLYPH_PAD_MASK = (3 << 0)
+	PCF_BYTE_MASK      = (1 << 2)
+	PCF_BIT_MASK       = (1 << 3)
+	PCF_SCAN_UNIT_MASK = (3 << 4)
 )
 
+// byteOrderForFormat returns the byte order that a table's format word
+// selects for every multi-byte value that follows it, independent of
+// PCF_GLYPH_PAD_MASK/PCF_SCAN_UNIT_MASK which govern bitmap scanlines.
+func byteOrderForFormat(format int32) binary.ByteOrder {
+	if format&PCF_BYTE_MASK != 0 {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
 type tocEntry struct {
 	typ    uint32
 	format uint32
@@ -37,7 +55,24 @@ type tocEntry struct {
 
 type fileHeader struct {
 	header     [4]byte
-	tableCount int
+	tableCount int32
+}
+
+func readFileHeader(r io.Reader) (fh fileHeader, err error) {
+	if err = binary.Read(r, binary.LittleEndian, &fh.header); err != nil {
+		return
+	}
+	err = binary.Read(r, binary.LittleEndian, &fh.tableCount)
+	return
+}
+
+func readTocEntry(r io.Reader) (toc tocEntry, err error) {
+	for _, v := range []*uint32{&toc.typ, &toc.format, &toc.size, &toc.offset} {
+		if err = binary.Read(r, binary.LittleEndian, v); err != nil {
+			return
+		}
+	}
+	return
 }
 
 type metricEntry struct {
@@ -52,6 +87,7 @@ type metricEntry struct {
 type metricTable struct {
 	table *tocEntry
 	format int32
+	order binary.ByteOrder
 	count int
 }
 
@@ -59,18 +95,19 @@ func (t *metricTable) read(f io.ReadSeeker) (err error) {
 	if _, err = f.Seek(int64(t.table.offset), 0); err != nil {
 		return
 	}
-	if err = bread(f, &t.format); err != nil {
+	if err = binary.Read(f, binary.LittleEndian, &t.format); err != nil {
 		return
 	}
+	t.order = byteOrderForFormat(t.format)
 	if (t.table.format & PCF_COMPRESSED_METRICS) != 0 {
 		var count int16
-		if err = breadSwap(f, &count); err != nil {
+		if err = binary.Read(f, t.order, &count); err != nil {
 			return
 		}
 		t.count = int(count)
 	} else {
 		var count int32
-		if err = breadSwap(f, &count); err != nil {
+		if err = binary.Read(f, t.order, &count); err != nil {
 			return
 		}
 		t.count = int(count)
@@ -88,7 +125,7 @@ func (t *metricTable) readMeticEntry(r io.ReadSeeker, i int, entry *metricEntry)
 			return
 		}
 		var b [5]byte
-		if _, err = r.Read(b[:]); err != nil {
+		if _, err = io.ReadFull(r, b[:]); err != nil {
 			return
 		}
 		entry.leftSidedBearing = int(b[0])
@@ -106,7 +143,7 @@ func (t *metricTable) readMeticEntry(r io.ReadSeeker, i int, entry *metricEntry)
 			return
 		}
 		var b [6]int16
-		if err = bread(r, b); err != nil {
+		if err = binary.Read(r, t.order, &b); err != nil {
 			return
 		}
 		entry.leftSidedBearing = int(b[0])
@@ -122,6 +159,7 @@ func (t *metricTable) readMeticEntry(r io.ReadSeeker, i int, entry *metricEntry)
 type bitmapTable struct {
 	table *tocEntry
 	format int32
+	order binary.ByteOrder
 	count int32
 	offsets []int32
 	bitmapSizes [4]int32
@@ -131,22 +169,23 @@ func (t *bitmapTable) read(r io.ReadSeeker) (err error) {
 	if _, err = r.Seek(int64(t.table.offset), 0); err != nil {
 		return
 	}
-	if err = bread(r, &t.format); err != nil {
+	if err = binary.Read(r, binary.LittleEndian, &t.format); err != nil {
 		return
 	}
-	if err = breadSwap(r, &t.count); err != nil {
+	t.order = byteOrderForFormat(t.format)
+	if err = binary.Read(r, t.order, &t.count); err != nil {
 		return
 	}
 	t.offsets = make([]int32, t.count)
-	if err = breadSwap(r, t.offsets); err != nil {
+	if err = binary.Read(r, t.order, t.offsets); err != nil {
 		return
 	}
-	if err = breadSwap(r, t.bitmapSizes[:]); err != nil {
+	if err = binary.Read(r, t.order, t.bitmapSizes[:]); err != nil {
 		return
 	}
 
 	if Debug {
-		log.Println("bitmap sizes", t.bitmapSizes, t.format&3)
+		log.Println("bitmap sizes", t.bitmapSizes, t.format&PCF_GLYPH_PAD_MASK)
 	}
 	return
 }
@@ -158,7 +197,11 @@ func (t *bitmapTable) readData(r io.ReadSeeker, i int) (b []byte, err error) {
 	}
 	off := int64(t.table.offset) + int64(8 + 4*len(t.offsets) + 16)
 	off += int64(t.offsets[i])
-	size := t.offsets[i+1] - t.offsets[i]
+	next := t.bitmapSizes[t.format&PCF_GLYPH_PAD_MASK]
+	if i+1 < len(t.offsets) {
+		next = t.offsets[i+1]
+	}
+	size := next - t.offsets[i]
 	if size < 0 {
 		err = fmt.Errorf("bitmapReadData: invalid offsets")
 		return
@@ -167,13 +210,14 @@ func (t *bitmapTable) readData(r io.ReadSeeker, i int) (b []byte, err error) {
 		return
 	}
 	b = make([]byte, size)
-	_, err = r.Read(b)
+	_, err = io.ReadFull(r, b)
 	return
 }
 
 type encodingTable struct {
 	table *tocEntry
 	format int32
+	order binary.ByteOrder
 	minCharOrByte2 int16
 	maxCharOrByte2 int16
 	minByte1 int16
@@ -186,27 +230,28 @@ func (t *encodingTable) read(r io.ReadSeeker) (err error) {
 	if _, err = r.Seek(int64(t.table.offset), 0); err != nil {
 		return
 	}
-	if err = bread(r, &t.format); err != nil {
+	if err = binary.Read(r, binary.LittleEndian, &t.format); err != nil {
 		return
 	}
-	if err = breadSwap(r, &t.minCharOrByte2); err != nil {
+	t.order = byteOrderForFormat(t.format)
+	if err = binary.Read(r, t.order, &t.minCharOrByte2); err != nil {
 		return
 	}
-	if err = breadSwap(r, &t.maxCharOrByte2); err != nil {
+	if err = binary.Read(r, t.order, &t.maxCharOrByte2); err != nil {
 		return
 	}
-	if err = breadSwap(r, &t.minByte1); err != nil {
+	if err = binary.Read(r, t.order, &t.minByte1); err != nil {
 		return
 	}
-	if err = breadSwap(r, &t.maxByte1); err != nil {
+	if err = binary.Read(r, t.order, &t.maxByte1); err != nil {
 		return
 	}
-	if err = breadSwap(r, &t.defChar); err != nil {
+	if err = binary.Read(r, t.order, &t.defChar); err != nil {
 		return
 	}
 	size := int(t.maxCharOrByte2-t.minCharOrByte2+1) * int(t.maxByte1-t.minByte1+1)
 	t.index = make([]int16, size)
-	if err = breadSwap(r, t.index); err != nil {
+	if err = binary.Read(r, t.order, t.index); err != nil {
 		return
 	}
 
@@ -217,86 +262,96 @@ func (t *encodingTable) read(r io.ReadSeeker) (err error) {
 	return
 }
 
+var errGlyphNotFound = errors.New("pcf: glyph not found")
+
+// lookup maps a character code to a glyph index. byte1 is the high
+// (row) byte and byte2 is the low (column) byte, matching the PCF
+// encoding table's minByte1/maxByte1 and minCharOrByte2/maxCharOrByte2
+// fields respectively.
 func (t *encodingTable) lookup(i int) (r int, err error) {
-	b1, b2 := i&0xff, i>>8
-	off := 0
-	if b2 == 0 {
-		off = b1-int(t.minCharOrByte2)
-	} else {
-		off = (b2-int(t.minByte1))*int(t.maxCharOrByte2-t.minCharOrByte2+1) +
-				(b1-int(t.minCharOrByte2))
+	byte1, byte2 := i>>8, i&0xff
+	if byte1 < int(t.minByte1) || byte1 > int(t.maxByte1) ||
+		byte2 < int(t.minCharOrByte2) || byte2 > int(t.maxCharOrByte2) {
+		err = errGlyphNotFound
+		return
 	}
 
+	off := (byte1-int(t.minByte1))*int(t.maxCharOrByte2-t.minCharOrByte2+1) +
+		(byte2 - int(t.minCharOrByte2))
+
 	if Debug {
-		log.Println("lookup", i, off, b1, b2)
+		log.Println("lookup", i, off, byte1, byte2)
 	}
 
-	r = int(t.index[off])
-	return
-}
-
-func _bread(r io.Reader, v interface{}, swap bool) error {
-	rv := reflect.ValueOf(v)
-	slice := []byte{}
-	rslice := (*reflect.SliceHeader)(unsafe.Pointer(&slice))
-
-	nelem := 0
-	switch rv.Type().Kind() {
-	case reflect.Ptr:
-		nelem = 1
-	case reflect.Slice:
-		nelem = rv.Len()
-	default:
-		return fmt.Errorf("_bread: unsupported type")
-	}
-	size := int(rv.Type().Elem().Size())
-	rslice.Data = rv.Pointer()
-	rslice.Len = size * nelem
-	rslice.Cap = rslice.Len
-
-	n, err := r.Read(slice)
-	if n != rslice.Len {
-		return err
-	}
-
-	if swap {
-		for i := 0; i < nelem; i++ {
-			start := i*size
-			for j := 0; j < size/2; j++ {
-				slice[start+j], slice[start+size-1-j] = slice[start+size-1-j], slice[start+j]
-			}
-		}
+	idx := t.index[off]
+	if idx < 0 {
+		err = errGlyphNotFound
+		return
 	}
 
-	return nil
+	r = int(idx)
+	return
 }
 
-func breadSwap(r io.Reader, v interface{}) error {
-	return _bread(r, v, true)
+// contains reports whether the encoding table has a glyph for i.
+func (t *encodingTable) contains(i int) bool {
+	_, err := t.lookup(i)
+	return err == nil
 }
 
-func bread(r io.Reader, v interface{}) error {
-	return _bread(r, v, false)
+// runes returns the character codes for every glyph in the encoding
+// table, in ascending order.
+func (t *encodingTable) runes() []rune {
+	var runes []rune
+	for byte1 := int(t.minByte1); byte1 <= int(t.maxByte1); byte1++ {
+		for byte2 := int(t.minCharOrByte2); byte2 <= int(t.maxCharOrByte2); byte2++ {
+			i := byte1<<8 | byte2
+			if t.contains(i) {
+				runes = append(runes, rune(i))
+			}
+		}
+	}
+	return runes
 }
 
 type PCFFile struct {
 	encoding *encodingTable
 	bitmap *bitmapTable
 	metric *metricTable
-	f *os.File
+	properties *propertiesTable
+	accelerators *acceleratorsTable
+	swidths *swidthsTable
+	glyphNames *glyphNamesTable
+	inkMetrics *metricTable
+	f io.ReadSeekCloser
 }
 
 var Debug bool
 
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error { return nil }
+
+// Open opens the named file as a PCF font.
 func Open(file string) (pf *PCFFile, err error) {
 	var f *os.File
 	f, err = os.Open(file)
 	if err != nil {
 		return
 	}
+	return decode(f)
+}
+
+func decode(r io.ReadSeeker) (pf *PCFFile, err error) {
+	f, ok := r.(io.ReadSeekCloser)
+	if !ok {
+		f = nopCloser{r}
+	}
 
 	var fh fileHeader
-	if err = bread(f, &fh); err != nil {
+	if fh, err = readFileHeader(f); err != nil {
 		return
 	}
 
@@ -304,15 +359,18 @@ func Open(file string) (pf *PCFFile, err error) {
 
 	var tocMetrics, tocBitmaps *tocEntry
 	var tocEncoding *tocEntry
+	var tocProperties, tocAccelerators, tocBdfAccelerators *tocEntry
+	var tocSwidths, tocGlyphNames, tocInkMetrics *tocEntry
 
 	if Debug {
 		log.Println("tableCount:", fh.tableCount)
 	}
-	for i := 0; i < fh.tableCount; i++ {
-		toc := &tocEntry{}
-		if err = bread(f, toc); err != nil {
+	for i := 0; i < int(fh.tableCount); i++ {
+		var e tocEntry
+		if e, err = readTocEntry(f); err != nil {
 			return
 		}
+		toc := &e
 		switch toc.typ {
 		case PCF_METRICS:
 			tocMetrics = toc
@@ -320,6 +378,18 @@ func Open(file string) (pf *PCFFile, err error) {
 			tocBitmaps = toc
 		case PCF_BDF_ENCODINGS:
 			tocEncoding = toc
+		case PCF_PROPERTIES:
+			tocProperties = toc
+		case PCF_ACCELERATORS:
+			tocAccelerators = toc
+		case PCF_BDF_ACCELERATORS:
+			tocBdfAccelerators = toc
+		case PCF_SWIDTHS:
+			tocSwidths = toc
+		case PCF_GLYPH_NAMES:
+			tocGlyphNames = toc
+		case PCF_INK_METRICS:
+			tocInkMetrics = toc
 		}
 	}
 
@@ -357,13 +427,57 @@ func Open(file string) (pf *PCFFile, err error) {
 		return
 	}
 
+	if tocProperties != nil {
+		pf.properties = &propertiesTable{table: tocProperties}
+		if err = pf.properties.read(f); err != nil {
+			return
+		}
+	}
+
+	if tocBdfAccelerators != nil {
+		pf.accelerators = &acceleratorsTable{table: tocBdfAccelerators}
+	} else if tocAccelerators != nil {
+		pf.accelerators = &acceleratorsTable{table: tocAccelerators}
+	}
+	if pf.accelerators != nil {
+		if err = pf.accelerators.read(f); err != nil {
+			return
+		}
+	}
+
+	if tocSwidths != nil {
+		pf.swidths = &swidthsTable{table: tocSwidths}
+		if err = pf.swidths.read(f); err != nil {
+			return
+		}
+	}
+
+	if tocGlyphNames != nil {
+		pf.glyphNames = &glyphNamesTable{table: tocGlyphNames}
+		if err = pf.glyphNames.read(f); err != nil {
+			return
+		}
+	}
+
+	if tocInkMetrics != nil {
+		pf.inkMetrics = &metricTable{table: tocInkMetrics}
+		if err = pf.inkMetrics.read(f); err != nil {
+			return
+		}
+	}
+
 	return
 }
 
 func (pf *PCFFile) Lookup(r rune) (b []byte, width int, err error) {
 	var i int
 	if i, err = pf.encoding.lookup(int(r)); err != nil {
-		return
+		if int(r) == int(pf.encoding.defChar) {
+			return
+		}
+		if i, err = pf.encoding.lookup(int(pf.encoding.defChar)); err != nil {
+			return
+		}
 	}
 	if b, err = pf.bitmap.readData(pf.f, i); err != nil {
 		return
@@ -372,6 +486,17 @@ func (pf *PCFFile) Lookup(r rune) (b []byte, width int, err error) {
 	return
 }
 
+// Contains reports whether the font has a glyph for r.
+func (pf *PCFFile) Contains(r rune) bool {
+	return pf.encoding.contains(int(r))
+}
+
+// Runes returns the character codes of every glyph in the font, in
+// ascending order.
+func (pf *PCFFile) Runes() []rune {
+	return pf.encoding.runes()
+}
+
 func (pf *PCFFile) DumpAscii(fname string, r rune) {
 	f, err := os.Create(fname)
 	if err != nil {
@@ -401,5 +526,3 @@ func (pf *PCFFile) DumpAscii(fname string, r rune) {
 	}
 	f.Close()
 }
-
-
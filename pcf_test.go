@@ -3,15 +3,123 @@ package pcf
 import (
 	"log"
 	"fmt"
+	"path/filepath"
 	"testing"
+
+	"golang.org/x/image/math/fixed"
 )
 
 func TestPcf(t *testing.T) {
 	Debug = true
-	if f, err := Open("wenquanyi_13px.pcf"); err == nil {
-		for i, r := range "456|123/\\测试!" {
-			log.Println("===", i, string(r))
-			f.DumpAscii(fmt.Sprintf("out%d", i), r)
+	f, err := Open("wenquanyi_13px.pcf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for i, r := range "456|123/\\测试!" {
+		log.Println("===", i, string(r))
+		f.DumpAscii(filepath.Join(dir, fmt.Sprintf("out%d", i)), r)
+	}
+}
+
+func TestPcfTables(t *testing.T) {
+	f, err := Open("wenquanyi_13px.pcf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Println("properties", f.Properties())
+	log.Println("accelerators", f.Accelerators())
+	log.Println("scalable widths", len(f.ScalableWidths()))
+	log.Println("glyph names", len(f.GlyphNames()))
+	log.Println("ink metrics", len(f.InkMetrics()))
+}
+
+func TestEncodingTableLookup(t *testing.T) {
+	et := &encodingTable{
+		minCharOrByte2: 0x20,
+		maxCharOrByte2: 0x7e,
+		minByte1:       0,
+		maxByte1:       0,
+		defChar:        0x20,
+		index:          make([]int16, 0x7e-0x20+1),
+	}
+	for i := range et.index {
+		et.index[i] = -1
+	}
+	et.index['A'-0x20] = 0
+
+	if !et.contains('A') {
+		t.Errorf("contains('A') = false, want true")
+	}
+	if et.contains('B') {
+		t.Errorf("contains('B') = true, want false (unmapped glyph)")
+	}
+	if et.contains(0x100) {
+		t.Errorf("contains(0x100) = true, want false (out of range)")
+	}
+	if _, err := et.lookup(0x100); err == nil {
+		t.Errorf("lookup(0x100) = nil error, want errGlyphNotFound")
+	}
+
+	runes := et.runes()
+	if len(runes) != 1 || runes[0] != 'A' {
+		t.Errorf("runes() = %v, want ['A']", runes)
+	}
+}
+
+// TestEncodingTableLookupTwoByte uses a table whose byte1 and byte2 ranges
+// don't overlap, so a lookup with the bytes swapped falls outside the
+// table's bounds instead of accidentally landing on the right glyph. This
+// is the shape of a real two-byte PCF encoding (e.g. GB2312/JISX0208
+// fonts), where byte1 is the row and byte2 is the column.
+func TestEncodingTableLookupTwoByte(t *testing.T) {
+	const minByte1, maxByte1 = 0x10, 0x20
+	const minByte2, maxByte2 = 0x30, 0x40
+	et := &encodingTable{
+		minCharOrByte2: minByte2,
+		maxCharOrByte2: maxByte2,
+		minByte1:       minByte1,
+		maxByte1:       maxByte1,
+		defChar:        -1,
+		index:          make([]int16, int(maxByte1-minByte1+1)*int(maxByte2-minByte2+1)),
+	}
+	for i := range et.index {
+		et.index[i] = -1
+	}
+
+	const byte1, byte2 = 0x11, 0x35
+	row := int(byte1-minByte1)*int(maxByte2-minByte2+1) + int(byte2-minByte2)
+	et.index[row] = 7
+
+	r := rune(byte1<<8 | byte2)
+	idx, err := et.lookup(int(r))
+	if err != nil {
+		t.Fatalf("lookup(%#x) = %v, want glyph 7", r, err)
+	}
+	if idx != 7 {
+		t.Errorf("lookup(%#x) = %d, want 7", r, idx)
+	}
+
+	// The byte-swapped code point has byte1=0x35 (out of [0x10,0x20]), so
+	// it must NOT resolve to the same glyph as r.
+	swapped := rune(byte2<<8 | byte1)
+	if et.contains(int(swapped)) {
+		t.Errorf("contains(%#x) = true, want false (byte1/byte2 swapped out of range)", swapped)
+	}
+}
+
+func TestPcfFace(t *testing.T) {
+	f, err := Open("wenquanyi_13px.pcf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	face := f.NewFace(nil)
+	defer face.Close()
+
+	dot := fixed.P(0, 0)
+	for _, r := range "456测试" {
+		if _, _, _, _, ok := face.Glyph(dot, r); !ok {
+			t.Errorf("Glyph(%q): not found", r)
 		}
 	}
 }
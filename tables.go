@@ -0,0 +1,334 @@
+package pcf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// MetricEntry is the exported form of a glyph's metric entry, as found in
+// the METRICS and INK_METRICS tables.
+type MetricEntry struct {
+	LeftSideBearing  int
+	RightSideBearing int
+	CharWidth        int
+	CharAscent       int
+	CharDescent      int
+	CharAttributes   int
+}
+
+func newMetricEntry(e metricEntry) MetricEntry {
+	return MetricEntry{
+		LeftSideBearing:  e.leftSidedBearing,
+		RightSideBearing: e.rightSidedBearing,
+		CharWidth:        e.charWidth,
+		CharAscent:       e.charAscent,
+		CharDescent:      e.charDescent,
+		CharAttributes:   e.charAttr,
+	}
+}
+
+// Accelerators holds the font-wide metrics and flags found in the
+// PCF_ACCELERATORS / PCF_BDF_ACCELERATORS table.
+type Accelerators struct {
+	NoOverlap      bool
+	ConstantMetrics bool
+	TerminalFont   bool
+	ConstantWidth  bool
+	InkInside      bool
+	InkMetrics     bool
+	DrawDirectionRTL bool
+
+	FontAscent  int
+	FontDescent int
+	MaxOverlap  int
+
+	MinBounds    MetricEntry
+	MaxBounds    MetricEntry
+	InkMinBounds MetricEntry
+	InkMaxBounds MetricEntry
+}
+
+func readCharInfo(r io.ReadSeeker, order binary.ByteOrder) (e metricEntry, err error) {
+	var b [6]int16
+	if err = binary.Read(r, order, &b); err != nil {
+		return
+	}
+	e.leftSidedBearing = int(b[0])
+	e.rightSidedBearing = int(b[1])
+	e.charWidth = int(b[2])
+	e.charAscent = int(b[3])
+	e.charDescent = int(b[4])
+	e.charAttr = int(b[5])
+	return
+}
+
+type acceleratorsTable struct {
+	table *tocEntry
+	format int32
+	order binary.ByteOrder
+	accel Accelerators
+}
+
+func (t *acceleratorsTable) read(r io.ReadSeeker) (err error) {
+	if _, err = r.Seek(int64(t.table.offset), 0); err != nil {
+		return
+	}
+	if err = binary.Read(r, binary.LittleEndian, &t.format); err != nil {
+		return
+	}
+	t.order = byteOrderForFormat(t.format)
+
+	var flags [8]byte
+	if _, err = io.ReadFull(r, flags[:]); err != nil {
+		return
+	}
+	t.accel.NoOverlap = flags[0] != 0
+	t.accel.ConstantMetrics = flags[1] != 0
+	t.accel.TerminalFont = flags[2] != 0
+	t.accel.ConstantWidth = flags[3] != 0
+	t.accel.InkInside = flags[4] != 0
+	t.accel.InkMetrics = flags[5] != 0
+	t.accel.DrawDirectionRTL = flags[6] != 0
+
+	var fontAscent, fontDescent, maxOverlap int32
+	if err = binary.Read(r, t.order, &fontAscent); err != nil {
+		return
+	}
+	if err = binary.Read(r, t.order, &fontDescent); err != nil {
+		return
+	}
+	if err = binary.Read(r, t.order, &maxOverlap); err != nil {
+		return
+	}
+	t.accel.FontAscent = int(fontAscent)
+	t.accel.FontDescent = int(fontDescent)
+	t.accel.MaxOverlap = int(maxOverlap)
+
+	var minBounds, maxBounds metricEntry
+	if minBounds, err = readCharInfo(r, t.order); err != nil {
+		return
+	}
+	if maxBounds, err = readCharInfo(r, t.order); err != nil {
+		return
+	}
+	t.accel.MinBounds = newMetricEntry(minBounds)
+	t.accel.MaxBounds = newMetricEntry(maxBounds)
+
+	if t.table.format&PCF_ACCEL_W_INKBOUNDS != 0 {
+		var inkMinBounds, inkMaxBounds metricEntry
+		if inkMinBounds, err = readCharInfo(r, t.order); err != nil {
+			return
+		}
+		if inkMaxBounds, err = readCharInfo(r, t.order); err != nil {
+			return
+		}
+		t.accel.InkMinBounds = newMetricEntry(inkMinBounds)
+		t.accel.InkMaxBounds = newMetricEntry(inkMaxBounds)
+	} else {
+		t.accel.InkMinBounds = t.accel.MinBounds
+		t.accel.InkMaxBounds = t.accel.MaxBounds
+	}
+
+	return
+}
+
+type propertiesTable struct {
+	table *tocEntry
+	format int32
+	order binary.ByteOrder
+	props  map[string]interface{}
+}
+
+func readCString(data []byte, offset int32) string {
+	if offset < 0 || int(offset) >= len(data) {
+		return ""
+	}
+	rest := data[offset:]
+	if i := bytes.IndexByte(rest, 0); i >= 0 {
+		rest = rest[:i]
+	}
+	return string(rest)
+}
+
+func (t *propertiesTable) read(r io.ReadSeeker) (err error) {
+	if _, err = r.Seek(int64(t.table.offset), 0); err != nil {
+		return
+	}
+	if err = binary.Read(r, binary.LittleEndian, &t.format); err != nil {
+		return
+	}
+	t.order = byteOrderForFormat(t.format)
+	var nprops int32
+	if err = binary.Read(r, t.order, &nprops); err != nil {
+		return
+	}
+
+	type rawProp struct {
+		nameOffset int32
+		isString   bool
+		value      int32
+	}
+	raw := make([]rawProp, nprops)
+	for i := range raw {
+		if err = binary.Read(r, t.order, &raw[i].nameOffset); err != nil {
+			return
+		}
+		var isString [1]byte
+		if _, err = io.ReadFull(r, isString[:]); err != nil {
+			return
+		}
+		raw[i].isString = isString[0] != 0
+		if err = binary.Read(r, t.order, &raw[i].value); err != nil {
+			return
+		}
+	}
+
+	if pad := (4 - (nprops*9)%4) % 4; pad != 0 {
+		if _, err = r.Seek(int64(pad), 1); err != nil {
+			return
+		}
+	}
+
+	var stringSize int32
+	if err = binary.Read(r, t.order, &stringSize); err != nil {
+		return
+	}
+	strings := make([]byte, stringSize)
+	if _, err = io.ReadFull(r, strings); err != nil {
+		return
+	}
+
+	t.props = make(map[string]interface{}, len(raw))
+	for _, p := range raw {
+		name := readCString(strings, p.nameOffset)
+		if p.isString {
+			t.props[name] = readCString(strings, p.value)
+		} else {
+			t.props[name] = int(p.value)
+		}
+	}
+
+	return
+}
+
+type swidthsTable struct {
+	table *tocEntry
+	format int32
+	order binary.ByteOrder
+	widths []int32
+}
+
+func (t *swidthsTable) read(r io.ReadSeeker) (err error) {
+	if _, err = r.Seek(int64(t.table.offset), 0); err != nil {
+		return
+	}
+	if err = binary.Read(r, binary.LittleEndian, &t.format); err != nil {
+		return
+	}
+	t.order = byteOrderForFormat(t.format)
+	var count int32
+	if err = binary.Read(r, t.order, &count); err != nil {
+		return
+	}
+	t.widths = make([]int32, count)
+	err = binary.Read(r, t.order, t.widths)
+	return
+}
+
+type glyphNamesTable struct {
+	table *tocEntry
+	format int32
+	order binary.ByteOrder
+	names  []string
+}
+
+func (t *glyphNamesTable) read(r io.ReadSeeker) (err error) {
+	if _, err = r.Seek(int64(t.table.offset), 0); err != nil {
+		return
+	}
+	if err = binary.Read(r, binary.LittleEndian, &t.format); err != nil {
+		return
+	}
+	t.order = byteOrderForFormat(t.format)
+	var count int32
+	if err = binary.Read(r, t.order, &count); err != nil {
+		return
+	}
+	offsets := make([]int32, count)
+	if err = binary.Read(r, t.order, offsets); err != nil {
+		return
+	}
+	var stringSize int32
+	if err = binary.Read(r, t.order, &stringSize); err != nil {
+		return
+	}
+	strings := make([]byte, stringSize)
+	if _, err = io.ReadFull(r, strings); err != nil {
+		return
+	}
+	t.names = make([]string, count)
+	for i, off := range offsets {
+		t.names[i] = readCString(strings, off)
+	}
+	return
+}
+
+// Properties returns the font's PCF_PROPERTIES table as a map of XLFD
+// property names (e.g. FAMILY_NAME, POINT_SIZE, PIXEL_SIZE, FONT_ASCENT,
+// FONT_DESCENT, DEFAULT_CHAR) to either a string or an int value. It
+// returns an empty map if the font has no properties table.
+func (pf *PCFFile) Properties() map[string]interface{} {
+	if pf.properties == nil {
+		return map[string]interface{}{}
+	}
+	return pf.properties.props
+}
+
+// Accelerators returns the font's accelerator metrics, preferring
+// PCF_BDF_ACCELERATORS over PCF_ACCELERATORS when both are present.
+func (pf *PCFFile) Accelerators() Accelerators {
+	if pf.accelerators == nil {
+		return Accelerators{}
+	}
+	return pf.accelerators.accel
+}
+
+// ScalableWidths returns the font's PCF_SWIDTHS table, one scalable
+// width per glyph, parallel to the METRICS table. It returns nil if the
+// font has no scalable widths table.
+func (pf *PCFFile) ScalableWidths() []int32 {
+	if pf.swidths == nil {
+		return nil
+	}
+	return pf.swidths.widths
+}
+
+// GlyphNames returns the font's PCF_GLYPH_NAMES table, one name per
+// glyph, parallel to the METRICS table. It returns nil if the font has
+// no glyph names table.
+func (pf *PCFFile) GlyphNames() []string {
+	if pf.glyphNames == nil {
+		return nil
+	}
+	return pf.glyphNames.names
+}
+
+// InkMetrics returns the font's PCF_INK_METRICS table: the true inked
+// bounding box of each glyph, as opposed to the (possibly wider) device
+// metrics used for advance width. It returns nil if the font has no ink
+// metrics table.
+func (pf *PCFFile) InkMetrics() []MetricEntry {
+	if pf.inkMetrics == nil {
+		return nil
+	}
+	entries := make([]MetricEntry, pf.inkMetrics.count)
+	for i := range entries {
+		var e metricEntry
+		if err := pf.inkMetrics.readMeticEntry(pf.f, i, &e); err != nil {
+			return entries[:i]
+		}
+		entries[i] = newMetricEntry(e)
+	}
+	return entries
+}
@@ -0,0 +1,411 @@
+package pcf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"sort"
+)
+
+const pcfMagic = "\x01fcp"
+
+type writerGlyph struct {
+	r             rune
+	name          string
+	metric        MetricEntry
+	scalableWidth int
+	bitmap        *image.Alpha
+}
+
+// Writer builds a PCF file from a set of glyphs, symmetric to how
+// Open/PCFFile read one. Glyphs are written in the order they were
+// added via AddGlyph.
+type Writer struct {
+	w      io.WriteSeeker
+	format int32
+	props  map[string]interface{}
+	glyphs []writerGlyph
+}
+
+// NewWriter returns a Writer that encodes a PCF file to w.
+func NewWriter(w io.WriteSeeker) *Writer {
+	return &Writer{w: w, props: map[string]interface{}{}}
+}
+
+// SetFormat selects the glyph-pad, scan-unit and byte/bit-order flags
+// (the PCF_GLYPH_PAD_MASK/PCF_SCAN_UNIT_MASK/PCF_BYTE_MASK/PCF_BIT_MASK
+// bits) used to encode the BITMAPS table. The default is
+// PCF_DEFAULT_FORMAT: 1-byte padding, a 1-byte scan unit, LSB-first
+// bytes and bits.
+func (w *Writer) SetFormat(format int32) {
+	w.format = format
+}
+
+// SetProperty sets a PCF_PROPERTIES entry. v must be a string or an int.
+func (w *Writer) SetProperty(k string, v interface{}) {
+	w.props[k] = v
+}
+
+// AddGlyph adds a glyph to the font. bitmap's bounds define the glyph's
+// pixel width and height; m's LeftSideBearing/RightSideBearing and
+// CharAscent/CharDescent must be consistent with bitmap's size.
+// scalableWidth is the glyph's PCF_SWIDTHS entry (see Glyph.ScalableWidth);
+// pass 0 if the font has no scalable width for this glyph.
+func (w *Writer) AddGlyph(r rune, name string, m MetricEntry, scalableWidth int, bitmap *image.Alpha) error {
+	if bitmap == nil {
+		return fmt.Errorf("pcf: AddGlyph: nil bitmap")
+	}
+	w.glyphs = append(w.glyphs, writerGlyph{r: r, name: name, metric: m, scalableWidth: scalableWidth, bitmap: bitmap})
+	return nil
+}
+
+func fitsCompressedMetric(m MetricEntry) bool {
+	for _, v := range []int{m.LeftSideBearing, m.RightSideBearing, m.CharWidth, m.CharAscent, m.CharDescent} {
+		if v < -0x80 || v > 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+func writeCharInfo(buf *bytes.Buffer, m MetricEntry) {
+	b := [6]int16{
+		int16(m.LeftSideBearing), int16(m.RightSideBearing), int16(m.CharWidth),
+		int16(m.CharAscent), int16(m.CharDescent), int16(m.CharAttributes),
+	}
+	binary.Write(buf, binary.LittleEndian, b)
+}
+
+func boundsMetric(glyphs []writerGlyph, pick func(MetricEntry) int, min bool) MetricEntry {
+	var m MetricEntry
+	for i, g := range glyphs {
+		v := pick(g.metric)
+		if i == 0 {
+			m = g.metric
+		} else if (min && v < pick(m)) || (!min && v > pick(m)) {
+			m = g.metric
+		}
+	}
+	return m
+}
+
+// packBitmap is the inverse of unpackBitmap: it encodes alpha's pixels
+// (treated as set when non-zero) into rows padded and ordered per format.
+func packBitmap(alpha *image.Alpha, format int32) []byte {
+	width := alpha.Bounds().Dx()
+	height := alpha.Bounds().Dy()
+
+	pad := 1 << uint(format&PCF_GLYPH_PAD_MASK)
+	scanUnit := 1 << uint((format&PCF_SCAN_UNIT_MASK)>>4)
+	msbByteOrder := format&PCF_BYTE_MASK != 0
+	msbBitOrder := format&PCF_BIT_MASK != 0
+
+	rowBytes := ((width + pad*8 - 1) / (pad * 8)) * pad
+	out := make([]byte, rowBytes*height)
+
+	for y := 0; y < height; y++ {
+		row := out[y*rowBytes : (y+1)*rowBytes]
+		for x := 0; x < width; x++ {
+			if alpha.AlphaAt(alpha.Bounds().Min.X+x, alpha.Bounds().Min.Y+y).A == 0 {
+				continue
+			}
+
+			byteIndex := x / 8
+			bitInByte := x % 8
+
+			unit := byteIndex / scanUnit
+			byteInUnit := byteIndex % scanUnit
+			if !msbByteOrder {
+				byteInUnit = scanUnit - 1 - byteInUnit
+			}
+			actualByteIndex := unit*scanUnit + byteInUnit
+			if actualByteIndex >= len(row) {
+				continue
+			}
+
+			if msbBitOrder {
+				row[actualByteIndex] |= 1 << uint(7-bitInByte)
+			} else {
+				row[actualByteIndex] |= 1 << uint(bitInByte)
+			}
+		}
+	}
+
+	return out
+}
+
+// Close builds and writes the PCF file. It must be called exactly once,
+// after all glyphs have been added.
+func (w *Writer) Close() error {
+	n := len(w.glyphs)
+
+	compressed := n > 0
+	for _, g := range w.glyphs {
+		if !fitsCompressedMetric(g.metric) {
+			compressed = false
+			break
+		}
+	}
+	metricsFormat := int32(PCF_DEFAULT_FORMAT)
+	if compressed {
+		metricsFormat |= PCF_COMPRESSED_METRICS
+	}
+
+	var metricsBuf bytes.Buffer
+	if compressed {
+		binary.Write(&metricsBuf, binary.LittleEndian, int16(n))
+		for _, g := range w.glyphs {
+			m := g.metric
+			metricsBuf.Write([]byte{
+				byte(m.LeftSideBearing + 0x80),
+				byte(m.RightSideBearing + 0x80),
+				byte(m.CharWidth + 0x80),
+				byte(m.CharAscent + 0x80),
+				byte(m.CharDescent + 0x80),
+			})
+		}
+	} else {
+		binary.Write(&metricsBuf, binary.LittleEndian, int32(n))
+		for _, g := range w.glyphs {
+			writeCharInfo(&metricsBuf, g.metric)
+		}
+	}
+
+	// bitmapTable.read expects exactly n offsets (count is the glyph
+	// count, not len(offsets)-1): the last glyph's size comes from
+	// bitmapSizes, not a trailing sentinel offset.
+	bitmapsFormat := w.format
+	bitmapOffsets := make([]int32, n)
+	var bitmapData bytes.Buffer
+	for i, g := range w.glyphs {
+		bitmapOffsets[i] = int32(bitmapData.Len())
+		bitmapData.Write(packBitmap(g.bitmap, bitmapsFormat))
+	}
+
+	var bitmapsBuf bytes.Buffer
+	binary.Write(&bitmapsBuf, binary.LittleEndian, int32(n))
+	binary.Write(&bitmapsBuf, binary.LittleEndian, bitmapOffsets)
+	var bitmapSizes [4]int32
+	bitmapSizes[bitmapsFormat&PCF_GLYPH_PAD_MASK] = int32(bitmapData.Len())
+	binary.Write(&bitmapsBuf, binary.LittleEndian, bitmapSizes)
+	bitmapsBuf.Write(bitmapData.Bytes())
+
+	minByte1, maxByte1 := int16(0), int16(0)
+	minCharOrByte2, maxCharOrByte2 := int16(0), int16(0)
+	for i, g := range w.glyphs {
+		b1, b2 := int16(g.r&0xff), int16(g.r>>8)
+		if i == 0 {
+			minByte1, maxByte1 = b2, b2
+			minCharOrByte2, maxCharOrByte2 = b1, b1
+		} else {
+			if b2 < minByte1 {
+				minByte1 = b2
+			}
+			if b2 > maxByte1 {
+				maxByte1 = b2
+			}
+			if b1 < minCharOrByte2 {
+				minCharOrByte2 = b1
+			}
+			if b1 > maxCharOrByte2 {
+				maxCharOrByte2 = b1
+			}
+		}
+	}
+	index := make([]int16, int(maxCharOrByte2-minCharOrByte2+1)*int(maxByte1-minByte1+1))
+	for i := range index {
+		index[i] = -1
+	}
+	for i, g := range w.glyphs {
+		b1, b2 := int16(g.r&0xff), int16(g.r>>8)
+		off := int(b2-minByte1)*int(maxCharOrByte2-minCharOrByte2+1) + int(b1-minCharOrByte2)
+		index[off] = int16(i)
+	}
+	defChar := int16(-1)
+	if dc, ok := w.props["DEFAULT_CHAR"]; ok {
+		if r, ok := dc.(int); ok {
+			for i, g := range w.glyphs {
+				if int(g.r) == r {
+					defChar = int16(i)
+					break
+				}
+			}
+		}
+	}
+
+	var encodingBuf bytes.Buffer
+	binary.Write(&encodingBuf, binary.LittleEndian, minCharOrByte2)
+	binary.Write(&encodingBuf, binary.LittleEndian, maxCharOrByte2)
+	binary.Write(&encodingBuf, binary.LittleEndian, minByte1)
+	binary.Write(&encodingBuf, binary.LittleEndian, maxByte1)
+	binary.Write(&encodingBuf, binary.LittleEndian, defChar)
+	binary.Write(&encodingBuf, binary.LittleEndian, index)
+
+	var swidthsBuf bytes.Buffer
+	binary.Write(&swidthsBuf, binary.LittleEndian, int32(n))
+	for _, g := range w.glyphs {
+		swidth := g.scalableWidth
+		if swidth == 0 {
+			// No scalable width carried over: approximate it from the
+			// device width, same fallback EncodeBDF uses (bdf.go).
+			swidth = g.metric.CharWidth * 1000
+		}
+		binary.Write(&swidthsBuf, binary.LittleEndian, int32(swidth))
+	}
+
+	nameOffsets := make([]int32, n)
+	var nameStrings bytes.Buffer
+	for i, g := range w.glyphs {
+		nameOffsets[i] = int32(nameStrings.Len())
+		nameStrings.WriteString(g.name)
+		nameStrings.WriteByte(0)
+	}
+	var namesBuf bytes.Buffer
+	binary.Write(&namesBuf, binary.LittleEndian, int32(n))
+	binary.Write(&namesBuf, binary.LittleEndian, nameOffsets)
+	binary.Write(&namesBuf, binary.LittleEndian, int32(nameStrings.Len()))
+	namesBuf.Write(nameStrings.Bytes())
+
+	var accelBuf bytes.Buffer
+	if n > 0 {
+		minBounds := boundsMetric(w.glyphs, func(m MetricEntry) int { return m.LeftSideBearing }, true)
+		maxBounds := boundsMetric(w.glyphs, func(m MetricEntry) int { return m.RightSideBearing }, false)
+		fontAscent := boundsMetric(w.glyphs, func(m MetricEntry) int { return m.CharAscent }, false).CharAscent
+		fontDescent := boundsMetric(w.glyphs, func(m MetricEntry) int { return m.CharDescent }, false).CharDescent
+		constantWidth := true
+		for _, g := range w.glyphs {
+			if g.metric.CharWidth != w.glyphs[0].metric.CharWidth {
+				constantWidth = false
+				break
+			}
+		}
+
+		flags := [8]byte{}
+		if constantWidth {
+			flags[3] = 1
+		}
+		accelBuf.Write(flags[:])
+		binary.Write(&accelBuf, binary.LittleEndian, int32(fontAscent))
+		binary.Write(&accelBuf, binary.LittleEndian, int32(fontDescent))
+		binary.Write(&accelBuf, binary.LittleEndian, int32(0))
+		writeCharInfo(&accelBuf, minBounds)
+		writeCharInfo(&accelBuf, maxBounds)
+	}
+
+	propKeys := make([]string, 0, len(w.props))
+	for k := range w.props {
+		propKeys = append(propKeys, k)
+	}
+	sort.Strings(propKeys)
+
+	type rawProp struct {
+		name     string
+		isString bool
+		value    int32
+		str      string
+	}
+	rawProps := make([]rawProp, 0, len(propKeys))
+	var propStrings bytes.Buffer
+	nameOffsetOf := map[string]int32{}
+	for _, k := range propKeys {
+		if _, ok := nameOffsetOf[k]; !ok {
+			nameOffsetOf[k] = int32(propStrings.Len())
+			propStrings.WriteString(k)
+			propStrings.WriteByte(0)
+		}
+		switch v := w.props[k].(type) {
+		case string:
+			rawProps = append(rawProps, rawProp{name: k, isString: true, str: v})
+		case int:
+			rawProps = append(rawProps, rawProp{name: k, value: int32(v)})
+		default:
+			return fmt.Errorf("pcf: SetProperty %q: unsupported value type %T", k, v)
+		}
+	}
+	for i, p := range rawProps {
+		if p.isString {
+			rawProps[i].value = int32(propStrings.Len())
+			propStrings.WriteString(p.str)
+			propStrings.WriteByte(0)
+		}
+	}
+
+	var propsBuf bytes.Buffer
+	binary.Write(&propsBuf, binary.LittleEndian, int32(len(rawProps)))
+	for _, p := range rawProps {
+		binary.Write(&propsBuf, binary.LittleEndian, nameOffsetOf[p.name])
+		isString := byte(0)
+		if p.isString {
+			isString = 1
+		}
+		propsBuf.WriteByte(isString)
+		binary.Write(&propsBuf, binary.LittleEndian, p.value)
+	}
+	if pad := (4 - (len(rawProps)*9)%4) % 4; pad != 0 {
+		propsBuf.Write(make([]byte, pad))
+	}
+	binary.Write(&propsBuf, binary.LittleEndian, int32(propStrings.Len()))
+	propsBuf.Write(propStrings.Bytes())
+
+	type table struct {
+		typ    uint32
+		format int32
+		body   []byte
+	}
+	tables := []table{
+		{PCF_PROPERTIES, PCF_DEFAULT_FORMAT, propsBuf.Bytes()},
+		{PCF_METRICS, metricsFormat, metricsBuf.Bytes()},
+		{PCF_BITMAPS, bitmapsFormat, bitmapsBuf.Bytes()},
+		{PCF_BDF_ENCODINGS, PCF_DEFAULT_FORMAT, encodingBuf.Bytes()},
+		{PCF_SWIDTHS, PCF_DEFAULT_FORMAT, swidthsBuf.Bytes()},
+		{PCF_GLYPH_NAMES, PCF_DEFAULT_FORMAT, namesBuf.Bytes()},
+	}
+	if n > 0 {
+		tables = append(tables,
+			table{PCF_ACCELERATORS, PCF_DEFAULT_FORMAT, accelBuf.Bytes()},
+			table{PCF_BDF_ACCELERATORS, PCF_DEFAULT_FORMAT, accelBuf.Bytes()},
+		)
+	}
+
+	// Every table body above is prefixed with its own format word, except
+	// propsBuf/metricsBuf/bitmapsBuf/encodingBuf/swidthsBuf/namesBuf/accelBuf
+	// which do not include it: prepend it uniformly here.
+	headerSize := int64(len(pcfMagic) + 4)
+	tocSize := int64(len(tables)) * 16
+	offset := headerSize + tocSize
+
+	type tocOut struct {
+		typ, format, size, offset uint32
+	}
+	tocs := make([]tocOut, len(tables))
+	bodies := make([][]byte, len(tables))
+	for i, t := range tables {
+		var body bytes.Buffer
+		binary.Write(&body, binary.LittleEndian, t.format)
+		body.Write(t.body)
+		bodies[i] = body.Bytes()
+		tocs[i] = tocOut{typ: t.typ, format: uint32(t.format), size: uint32(len(bodies[i])), offset: uint32(offset)}
+		offset += int64(len(bodies[i]))
+	}
+
+	if _, err := w.w.Write([]byte(pcfMagic)); err != nil {
+		return err
+	}
+	if err := binary.Write(w.w, binary.LittleEndian, int32(len(tables))); err != nil {
+		return err
+	}
+	for _, t := range tocs {
+		if err := binary.Write(w.w, binary.LittleEndian, t); err != nil {
+			return err
+		}
+	}
+	for _, b := range bodies {
+		if _, err := w.w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package pcf
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"testing"
+
+	"golang.org/x/image/math/fixed"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	f, err := os.CreateTemp("", "pcf-writer-*.pcf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	w := NewWriter(f)
+	w.SetProperty("FAMILY_NAME", "Test")
+	w.SetProperty("PIXEL_SIZE", 8)
+
+	bitmap := image.NewAlpha(image.Rect(0, 0, 4, 6))
+	bitmap.SetAlpha(0, 0, color.Alpha{A: 0xff})
+	bitmap.SetAlpha(3, 5, color.Alpha{A: 0xff})
+	m := MetricEntry{RightSideBearing: 4, CharWidth: 5, CharAscent: 5, CharDescent: 1}
+	if err := w.AddGlyph('A', "A", m, 0, bitmap); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pf, err := Open(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pf.Properties()["FAMILY_NAME"]; got != "Test" {
+		t.Errorf("FAMILY_NAME = %v, want Test", got)
+	}
+	b, _, err := pf.Lookup('A')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) == 0 {
+		t.Errorf("expected non-empty bitmap data")
+	}
+
+	face := pf.NewFace(nil)
+	defer face.Close()
+	_, mask, _, _, ok := face.Glyph(fixed.P(0, 0), 'A')
+	if !ok {
+		t.Fatal("Glyph('A'): not found")
+	}
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 4; x++ {
+			want := (x == 0 && y == 0) || (x == 3 && y == 5)
+			got := mask.At(x, y).(color.Alpha).A != 0
+			if got != want {
+				t.Errorf("mask.At(%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}